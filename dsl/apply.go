@@ -0,0 +1,204 @@
+package dsl
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// applyBinding is the variable name the second argument of an `apply` call
+// is evaluated against, e.g. `apply(token_balance(addr, user), it / 1e18)`.
+// hclsyntax has no arrow-lambda syntax, so `apply` can't be a plain cty
+// function taking a lambda value; instead it's handled specially at decode
+// time (see evalAttr) and its second argument is just a normal expression
+// that happens to see `it` bound to the (eventually resolved) first
+// argument.
+const applyBinding = "it"
+
+// FutureType is a cty capsule type wrapping a thunk that lazily produces a
+// value. `apply(...)` uses it to defer evaluating its second argument; the
+// first argument (e.g. a ChainFunctionProvider call) is evaluated as soon
+// as the `apply` call itself is.
+var FutureType = cty.Capsule("future", reflect.TypeOf(Future{}))
+
+// Future wraps a deferred computation. Resolve runs the thunk at most once
+// and caches the outcome, so resolving the same Future from multiple
+// EvalSave/EvalTransforms passes, or from a nested `apply`, doesn't
+// re-invoke the underlying provider call.
+type Future struct {
+	thunk    func() (cty.Value, error)
+	resolved bool
+	value    cty.Value
+	err      error
+}
+
+// NewFuture wraps thunk in a Future cty.Value.
+func NewFuture(thunk func() (cty.Value, error)) cty.Value {
+	return cty.CapsuleVal(FutureType, &Future{thunk: thunk})
+}
+
+// Resolve runs the wrapped thunk (once) and returns its result.
+func (f *Future) Resolve() (cty.Value, error) {
+	if !f.resolved {
+		f.value, f.err = f.thunk()
+		f.resolved = true
+	}
+
+	return f.value, f.err
+}
+
+// IsFuture reports whether v is a Future capsule value.
+func IsFuture(v cty.Value) bool {
+	return v.Type().Equals(FutureType)
+}
+
+// asFuture extracts the *Future from a Future capsule value.
+func asFuture(v cty.Value) *Future {
+	return v.EncapsulatedValue().(*Future)
+}
+
+// evalAttr evaluates a single attribute expression against ctx, special-
+// casing a top-level call to `apply` since it can't be expressed as an
+// ordinary cty function (its second argument is an expression, not a
+// value). Every other expression is evaluated the normal HCL way.
+func evalAttr(expr hcl.Expression, ctx *hcl.EvalContext) (cty.Value, error) {
+	if call, ok := expr.(*hclsyntax.FunctionCallExpr); ok && call.Name == "apply" {
+		return evalApply(call, ctx)
+	}
+
+	v, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		return cty.NilVal, diags.Errs()[0]
+	}
+
+	return v, nil
+}
+
+// evalApply implements the `apply(value, expr)` intrinsic: value is
+// evaluated eagerly (recursing through evalAttr so a nested `apply` is
+// resolved at most once), and expr is evaluated lazily, once value is
+// available, with `it` bound to value's resolved result. The whole call
+// itself evaluates to a Future, so chaining `apply(apply(x, a), b)` only
+// ever runs x's provider call once.
+func evalApply(call *hclsyntax.FunctionCallExpr, ctx *hcl.EvalContext) (cty.Value, error) {
+	if len(call.Args) != 2 {
+		return cty.NilVal, fmt.Errorf("apply: expected 2 arguments (value, expr), got %d", len(call.Args))
+	}
+
+	value, err := evalAttr(call.Args[0], ctx)
+	if err != nil {
+		return cty.NilVal, err
+	}
+
+	bodyExpr := call.Args[1]
+
+	return NewFuture(func() (cty.Value, error) {
+		resolved := value
+		if IsFuture(resolved) {
+			v, err := asFuture(resolved).Resolve()
+			if err != nil {
+				return cty.NilVal, err
+			}
+			resolved = v
+		}
+
+		childCtx := ctx.NewChild()
+		childCtx.Variables = map[string]cty.Value{applyBinding: resolved}
+
+		out, diags := bodyExpr.Value(childCtx)
+		if diags.HasErrors() {
+			return cty.NilVal, diags.Errs()[0]
+		}
+
+		return out, nil
+	}), nil
+}
+
+// evalAttrsApplyAware evaluates every attribute of body against ctx,
+// understanding the `apply` intrinsic. It's what EvalTransforms/EvalSave
+// use instead of gohcl.DecodeBody so `apply(...)` expressions in a
+// transform/save block are handled rather than erroring as an unknown
+// function call.
+func evalAttrsApplyAware(body hcl.Body, ctx *hcl.EvalContext) (map[string]cty.Value, error) {
+	attrs, diags := body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags.Errs()[0]
+	}
+
+	out := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		v, err := evalAttr(attr.Expr, ctx)
+		if err != nil {
+			return nil, err
+		}
+		out[name] = v
+	}
+
+	return out, nil
+}
+
+// resolveFutures walks v and resolves any Future values it finds (including
+// ones nested inside objects, maps, tuples and lists), flattening chained
+// `apply` calls into their final, concrete cty.Value so sinks never see a
+// Future leaking out of EvalSave/EvalTransforms.
+func resolveFutures(v cty.Value) (cty.Value, error) {
+	if !v.IsKnown() || v.IsNull() {
+		return v, nil
+	}
+
+	if IsFuture(v) {
+		resolved, err := asFuture(v).Resolve()
+		if err != nil {
+			return cty.NilVal, err
+		}
+
+		return resolveFutures(resolved)
+	}
+
+	switch {
+	case v.Type().IsObjectType() || v.Type().IsMapType():
+		vals := make(map[string]cty.Value)
+		it := v.ElementIterator()
+		for it.Next() {
+			k, ev := it.Element()
+			resolved, err := resolveFutures(ev)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			vals[k.AsString()] = resolved
+		}
+
+		if len(vals) == 0 {
+			return v, nil
+		}
+		if v.Type().IsObjectType() {
+			return cty.ObjectVal(vals), nil
+		}
+		return cty.MapVal(vals), nil
+
+	case v.Type().IsTupleType() || v.Type().IsListType():
+		items := make([]cty.Value, 0)
+		it := v.ElementIterator()
+		for it.Next() {
+			_, ev := it.Element()
+			resolved, err := resolveFutures(ev)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			items = append(items, resolved)
+		}
+
+		if len(items) == 0 {
+			return v, nil
+		}
+		if v.Type().IsTupleType() {
+			return cty.TupleVal(items), nil
+		}
+		return cty.ListVal(items), nil
+	}
+
+	return v, nil
+}
@@ -0,0 +1,278 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/chainbound/apollo/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SourceGraphQL is the `source` attribute value that routes a contract,
+// event or query through a go-ethereum GraphQL endpoint (the `graphql`
+// service, usually exposed at `/graphql`) instead of the default
+// eth_getLogs/eth_call JSON-RPC path. This is mainly useful for wide
+// historical event scans, since a single GraphQL query can fetch many
+// blocks' worth of logs in one round-trip.
+const SourceGraphQL = "graphql"
+
+// graphQLRequest is the standard GraphQL-over-HTTP envelope.
+type graphQLRequest struct {
+	Query string `json:"query"`
+}
+
+// graphQLResponse wraps the raw JSON result, deferring decoding of `data`
+// until the caller knows which shape (logs or call) to expect.
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// GraphQLClient executes batched queries against a go-ethereum GraphQL
+// endpoint and translates the results into types.CallResult, so they can
+// be fed into EvalSave like any other chain source.
+type GraphQLClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewGraphQLClient returns a client for the go-ethereum GraphQL service at
+// endpoint (e.g. "https://rpc.example.com/graphql").
+func NewGraphQLClient(endpoint string) *GraphQLClient {
+	return &GraphQLClient{
+		endpoint: endpoint,
+		client:   http.DefaultClient,
+	}
+}
+
+func (g *GraphQLClient) do(query string, out interface{}) error {
+	body, err := json.Marshal(graphQLRequest{Query: query})
+	if err != nil {
+		return fmt.Errorf("graphql: encoding request: %w", err)
+	}
+
+	resp, err := g.client.Post(g.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("graphql: request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("graphql: reading response: %w", err)
+	}
+
+	var gqlResp graphQLResponse
+	if err := json.Unmarshal(raw, &gqlResp); err != nil {
+		return fmt.Errorf("graphql: decoding response: %w", err)
+	}
+
+	if len(gqlResp.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", gqlResp.Errors[0].Message)
+	}
+
+	if err := json.Unmarshal(gqlResp.Data, out); err != nil {
+		return fmt.Errorf("graphql: decoding data: %w", err)
+	}
+
+	return nil
+}
+
+// logsQueryResult mirrors the subset of go-ethereum's GraphQL schema we
+// read back from a `logs(filter: {...})` query.
+type logsQueryResult struct {
+	Logs []struct {
+		Account struct {
+			Address string `json:"address"`
+		} `json:"account"`
+		Topics      []string       `json:"topics"`
+		Data        string         `json:"data"`
+		Index       hexutil.Uint64 `json:"index"`
+		Transaction struct {
+			Hash  string         `json:"hash"`
+			Index hexutil.Uint64 `json:"index"`
+			Block struct {
+				Number    hexutil.Uint64 `json:"number"`
+				Hash      string         `json:"hash"`
+				Timestamp hexutil.Uint64 `json:"timestamp"`
+			} `json:"block"`
+		} `json:"transaction"`
+	} `json:"logs"`
+}
+
+// buildLogsQuery constructs a `logs(filter: {...})` query for the given
+// address/topic filter over [fromBlock, toBlock].
+func buildLogsQuery(address common.Address, topics []common.Hash, fromBlock, toBlock int64) string {
+	topicList := "[]"
+	if len(topics) > 0 {
+		topicList = "[\""
+		for i, t := range topics {
+			if i > 0 {
+				topicList += "\",\""
+			}
+			topicList += t.Hex()
+		}
+		topicList += "\"]"
+	}
+
+	return fmt.Sprintf(`{
+		logs(filter: {fromBlock: %d, toBlock: %d, addresses: ["%s"], topics: [%s]}) {
+			account { address }
+			topics
+			data
+			index
+			transaction {
+				hash
+				index
+				block { number hash timestamp }
+			}
+		}
+	}`, fromBlock, toBlock, address.Hex(), topicList)
+}
+
+// callQueryResult mirrors go-ethereum's `block(number:){ call(data:{...}) }`
+// result shape: `call` is nested under `block`, not top-level.
+type callQueryResult struct {
+	Block struct {
+		Call struct {
+			Data   string         `json:"data"`
+			Status hexutil.Uint64 `json:"status"`
+		} `json:"call"`
+	} `json:"block"`
+}
+
+// buildCallQuery constructs a `call(data: {...})` query that executes
+// calldata against `to` at blockNumber.
+func buildCallQuery(to common.Address, calldata []byte, blockNumber int64) string {
+	return fmt.Sprintf(`{
+		block(number: %d) {
+			call(data: {to: "%s", data: "0x%x"}) {
+				data
+				status
+			}
+		}
+	}`, blockNumber, to.Hex(), calldata)
+}
+
+// FetchLogs runs a batched logs query over [fromBlock, toBlock] for the
+// given event and translates every returned log into a types.CallResult,
+// decoding both its indexed (topic) and non-indexed (data) arguments into
+// Outputs so EvalSave/GenerateContextVars see the same shape they'd get
+// from the eth_getLogs path.
+func (g *GraphQLClient) FetchLogs(chain types.Chain, event *EventSchema, contractAddr common.Address, topics []common.Hash, fromBlock, toBlock int64) ([]types.CallResult, error) {
+	var res logsQueryResult
+	if err := g.do(buildLogsQuery(contractAddr, topics, fromBlock, toBlock), &res); err != nil {
+		return nil, err
+	}
+
+	eventAbi, ok := event.Abi.Events[event.Name()]
+	if !ok {
+		return nil, fmt.Errorf("graphql: event %q not found in ABI", event.Name())
+	}
+
+	results := make([]types.CallResult, 0, len(res.Logs))
+	for _, l := range res.Logs {
+		outputs, err := decodeEventLog(eventAbi.Inputs, toHashes(l.Topics), common.FromHex(l.Data))
+		if err != nil {
+			return nil, fmt.Errorf("graphql: decoding event %q: %w", event.Name(), err)
+		}
+
+		results = append(results, types.CallResult{
+			Chain:           chain,
+			Type:            types.Event,
+			EventName:       event.Name(),
+			ContractAddress: common.HexToAddress(l.Account.Address),
+			BlockNumber:     uint64(l.Transaction.Block.Number),
+			BlockHash:       common.HexToHash(l.Transaction.Block.Hash),
+			Timestamp:       int64(l.Transaction.Block.Timestamp),
+			TxHash:          common.HexToHash(l.Transaction.Hash),
+			TxIndex:         uint(l.Transaction.Index),
+			Outputs:         outputs,
+		})
+	}
+
+	return results, nil
+}
+
+// decodeEventLog splits fields into indexed and non-indexed arguments and
+// decodes topics/data into a single Outputs-keyed map, the way the
+// eth_getLogs path's log decoding does.
+func decodeEventLog(fields abi.Arguments, topics []common.Hash, data []byte) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{})
+
+	if err := fields.NonIndexed().UnpackIntoMap(outputs, data); err != nil {
+		return nil, fmt.Errorf("unpacking data: %w", err)
+	}
+
+	// The first topic is the event signature hash, not an indexed argument.
+	if len(topics) > 0 {
+		topics = topics[1:]
+	}
+
+	var indexed abi.Arguments
+	for _, f := range fields {
+		if f.Indexed {
+			indexed = append(indexed, f)
+		}
+	}
+
+	if err := abi.ParseTopicsIntoMap(outputs, indexed, topics); err != nil {
+		return nil, fmt.Errorf("unpacking topics: %w", err)
+	}
+
+	return outputs, nil
+}
+
+// toHashes converts the GraphQL response's hex topic strings into
+// common.Hash values.
+func toHashes(topics []string) []common.Hash {
+	hashes := make([]common.Hash, len(topics))
+	for i, t := range topics {
+		hashes[i] = common.HexToHash(t)
+	}
+
+	return hashes
+}
+
+// FetchCall executes a single `method` block at blockNumber through the
+// `call` GraphQL field, decodes the raw return data against the method's
+// ABI outputs the same way the JSON-RPC eth_call path does, and returns a
+// types.CallResult ready for EvalSave.
+func (g *GraphQLClient) FetchCall(chain types.Chain, contract *ContractSchema, method *MethodSchema, to common.Address, calldata []byte, blockNumber int64) (types.CallResult, error) {
+	var res callQueryResult
+	if err := g.do(buildCallQuery(to, calldata, blockNumber), &res); err != nil {
+		return types.CallResult{}, err
+	}
+
+	if res.Block.Call.Status == 0 {
+		return types.CallResult{}, fmt.Errorf("graphql: call to %s reverted at block %d", to, blockNumber)
+	}
+
+	values, err := contract.Abi.Unpack(method.Name(), common.FromHex(res.Block.Call.Data))
+	if err != nil {
+		return types.CallResult{}, fmt.Errorf("graphql: decoding method %q: %w", method.Name(), err)
+	}
+
+	outputs := make(map[string]interface{}, len(method.Outputs))
+	for i, name := range method.Outputs {
+		if i < len(values) {
+			outputs[name] = values[i]
+		}
+	}
+
+	return types.CallResult{
+		Chain:           chain,
+		Type:            types.Method,
+		ContractAddress: to,
+		BlockNumber:     uint64(blockNumber),
+		Outputs:         outputs,
+	}, nil
+}
@@ -0,0 +1,224 @@
+package dsl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/gocty"
+)
+
+// IndexSchema declares a secondary index over a query's saved outputs, e.g.
+//
+//	index "by_pool_block" {
+//	  keys = [pool_address, blocknumber]
+//	}
+//
+// so downstream sinks can build range-scannable indexes instead of scanning
+// every row.
+type IndexSchema struct {
+	Name string `hcl:"name,label"`
+	// Keys is kept unevaluated: each element is a bare output name, which
+	// EvalIndexes looks up in the saved outputs.
+	Keys hcl.Expression `hcl:"keys"`
+}
+
+// IndexSink receives one encoded key per declared index, per saved row, so
+// output drivers (CSV, Postgres, Pebble, ...) can build their own
+// range-scannable representation of it.
+type IndexSink interface {
+	WriteIndex(indexName string, key []byte, rowID string) error
+}
+
+// EncodeIndexKey encodes an ordered tuple of cty.Values into a single byte
+// slice whose lexicographic (byte-wise) ordering matches the tuple's
+// natural ordering. Numbers are encoded as a sign byte followed by a
+// fixed-width big-endian magnitude, bit-inverted for negatives, so
+// negatives sort before zero and zero before positives; addresses (0x-
+// prefixed 20-byte hex strings) are encoded as their raw 20 bytes; every
+// other string is length-prefixed so shorter strings don't get swallowed
+// by a longer one sharing the same prefix.
+func EncodeIndexKey(values []cty.Value) ([]byte, error) {
+	var out []byte
+	for _, v := range values {
+		enc, err := encodeIndexValue(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, enc...)
+	}
+
+	return out, nil
+}
+
+func encodeIndexValue(v cty.Value) ([]byte, error) {
+	switch v.Type() {
+	case cty.Number:
+		return encodeIndexNumber(v)
+	case cty.String:
+		s := v.AsString()
+		if isAddressLike(s) {
+			return addressBytes(s), nil
+		}
+		return encodeIndexString(s), nil
+	default:
+		return nil, fmt.Errorf("index: unsupported key type %s", v.Type().FriendlyName())
+	}
+}
+
+// numberKeyWidth is wide enough to hold a full uint256 magnitude (32 bytes),
+// since reserves, wei balances and similar on-chain quantities routinely
+// exceed int64/uint64.
+const numberKeyWidth = 32
+
+// encodeIndexNumber encodes a cty.Number as a fixed-width, sign-prefixed
+// big-endian big.Int so that byte-wise comparison matches numeric ordering
+// across the full uint256 range: a leading sign byte buckets negative <
+// zero < positive, and within the negative bucket the magnitude bytes are
+// bit-inverted so a more-negative value (bigger magnitude) still sorts
+// before a less-negative one. Errors rather than silently truncating if
+// the value isn't an exact integer.
+func encodeIndexNumber(v cty.Value) ([]byte, error) {
+	var bf big.Float
+	if err := gocty.FromCtyValue(v, &bf); err != nil {
+		return nil, fmt.Errorf("index: decoding number key: %w", err)
+	}
+
+	i, acc := bf.Int(nil)
+	if acc != big.Exact {
+		return nil, fmt.Errorf("index: key value %s is not an exact integer", bf.String())
+	}
+
+	return encodeBigInt(i)
+}
+
+// encodeBigInt encodes i as a 1-byte sign marker followed by a
+// numberKeyWidth-byte big-endian magnitude. See encodeIndexNumber for the
+// ordering rationale.
+func encodeBigInt(i *big.Int) ([]byte, error) {
+	mag := new(big.Int).Abs(i).Bytes()
+	if len(mag) > numberKeyWidth {
+		return nil, fmt.Errorf("index: key value %s exceeds %d-byte range", i.String(), numberKeyWidth)
+	}
+
+	buf := make([]byte, 1+numberKeyWidth)
+	copy(buf[1+numberKeyWidth-len(mag):], mag)
+
+	switch i.Sign() {
+	case -1:
+		buf[0] = 0x00
+		for j := 1; j < len(buf); j++ {
+			buf[j] = ^buf[j]
+		}
+	case 0:
+		buf[0] = 0x01
+	default:
+		buf[0] = 0x02
+	}
+
+	return buf, nil
+}
+
+// encodeIndexString length-prefixes s (4-byte big-endian length) so that
+// two strings where one is a prefix of the other still compare correctly.
+func encodeIndexString(s string) []byte {
+	buf := make([]byte, 4+len(s))
+	binary.BigEndian.PutUint32(buf, uint32(len(s)))
+	copy(buf[4:], s)
+
+	return buf
+}
+
+// isAddressLike reports whether s looks like a hex-encoded 20-byte Ethereum
+// address, as produced by common.Address.String() and stored in outputs by
+// GenerateContextVars.
+func isAddressLike(s string) bool {
+	return len(s) == 42 && strings.HasPrefix(s, "0x")
+}
+
+// addressBytes decodes a 0x-prefixed hex address into its raw 20 bytes.
+func addressBytes(s string) []byte {
+	buf := make([]byte, 20)
+	for i := 0; i < 20; i++ {
+		hi := hexNibble(s[2+i*2])
+		lo := hexNibble(s[3+i*2])
+		buf[i] = hi<<4 | lo
+	}
+
+	return buf
+}
+
+func hexNibble(b byte) byte {
+	switch {
+	case b >= '0' && b <= '9':
+		return b - '0'
+	case b >= 'a' && b <= 'f':
+		return b - 'a' + 10
+	case b >= 'A' && b <= 'F':
+		return b - 'A' + 10
+	default:
+		return 0
+	}
+}
+
+// LowerBound returns the inclusive lower bound for a range scan over every
+// key sharing prefix (a key tuple with fewer elements than the full index).
+func LowerBound(prefix []byte) []byte {
+	return prefix
+}
+
+// UpperBound returns the exclusive upper bound for a range scan over every
+// key sharing prefix: the prefix with its last byte incremented, carrying
+// as needed. A prefix of all 0xFF bytes has no finite upper bound and
+// UpperBound returns nil, meaning "scan to the end".
+func UpperBound(prefix []byte) []byte {
+	upper := make([]byte, len(prefix))
+	copy(upper, prefix)
+
+	for i := len(upper) - 1; i >= 0; i-- {
+		if upper[i] < 0xFF {
+			upper[i]++
+			return upper[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// EvalIndexes resolves every declared index against a row's saved outputs
+// and writes the resulting (indexName, encodedKey, rowID) triples to sink.
+func (q *QuerySchema) EvalIndexes(outputs map[string]cty.Value, rowID string, sink IndexSink) error {
+	for _, idx := range q.Indexes {
+		keyExprs, diags := hcl.ExprList(idx.Keys)
+		if diags.HasErrors() {
+			return fmt.Errorf("index %q: %w", idx.Name, diags.Errs()[0])
+		}
+
+		values := make([]cty.Value, 0, len(keyExprs))
+		for _, keyExpr := range keyExprs {
+			key := hcl.ExprAsKeyword(keyExpr)
+			if key == "" {
+				return fmt.Errorf("index %q: keys must be bare output names", idx.Name)
+			}
+			v, ok := outputs[key]
+			if !ok {
+				return fmt.Errorf("index %q: output %q not found in save block", idx.Name, key)
+			}
+			values = append(values, v)
+		}
+
+		encoded, err := EncodeIndexKey(values)
+		if err != nil {
+			return fmt.Errorf("index %q: %w", idx.Name, err)
+		}
+
+		if err := sink.WriteIndex(idx.Name, encoded, rowID); err != nil {
+			return fmt.Errorf("index %q: writing: %w", idx.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+package dsl
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/chainbound/apollo/types"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// canonicalMulticallAddress is the address of the Multicall3 deployment
+// (https://github.com/mds1/multicall3), which is identical across every
+// chain it's deployed on via a deterministic deployer.
+var canonicalMulticallAddress = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// multicall3ABI is the minimal ABI needed to pack/unpack aggregate3.
+const multicall3ABI = `[{
+	"inputs": [{
+		"components": [
+			{"name": "target", "type": "address"},
+			{"name": "allowFailure", "type": "bool"},
+			{"name": "callData", "type": "bytes"}
+		],
+		"name": "calls",
+		"type": "tuple[]"
+	}],
+	"name": "aggregate3",
+	"outputs": [{
+		"components": [
+			{"name": "success", "type": "bool"},
+			{"name": "returnData", "type": "bytes"}
+		],
+		"name": "returnData",
+		"type": "tuple[]"
+	}],
+	"stateMutability": "payable",
+	"type": "function"
+}]`
+
+var multicall3Abi abi.ABI
+
+func init() {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		panic(fmt.Errorf("multicall: parsing Multicall3 ABI: %w", err))
+	}
+	multicall3Abi = parsed
+}
+
+// Call3 mirrors Multicall3's `Call3` struct.
+type Call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result mirrors Multicall3's `Result` struct.
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// MulticallAddress returns the Multicall3 deployment address to use for
+// this schema: the explicit MulticallAddress_ override if set, otherwise
+// the canonical 0xcA11... deployment.
+func (s DynamicSchema) MulticallAddress() common.Address {
+	if s.MulticallAddress_ != "" {
+		return common.HexToAddress(s.MulticallAddress_)
+	}
+
+	return canonicalMulticallAddress
+}
+
+// BuildAggregate3Calldata ABI-encodes a batch of method calls into a single
+// aggregate3 call, so the runtime can issue one eth_call per block instead
+// of one per method.
+func BuildAggregate3Calldata(calls []Call3) ([]byte, error) {
+	return multicall3Abi.Pack("aggregate3", calls)
+}
+
+// DecodeAggregate3Result decodes the raw return data of an aggregate3 call
+// back into the per-call Result slice, preserving call order.
+func DecodeAggregate3Result(data []byte) ([]Result, error) {
+	var results []Result
+	if err := multicall3Abi.UnpackIntoInterface(&results, "aggregate3", data); err != nil {
+		return nil, fmt.Errorf("multicall: unpacking aggregate3 results: %w", err)
+	}
+
+	return results, nil
+}
+
+// MethodCall pairs a MethodSchema with the contract it's being called on
+// and its ABI-encoded calldata, so it can be packed into an aggregate3 batch
+// and its result routed back to the right method afterwards.
+type MethodCall struct {
+	Contract *ContractSchema
+	Method   *MethodSchema
+	CallData []byte
+}
+
+// BuildMethodCalls ABI-encodes the given method blocks (e.g. a contract's
+// own methods, or ones nested under an event's block_offset) against
+// contract's ABI into a batch of MethodCalls. ToCall3 turns the batch into
+// the Call3 input of BuildAggregate3Calldata, with AllowFailure set on every
+// call so a single reverting call doesn't kill the whole batch; callers
+// distinguish success per-call via DecodeMethodResults.
+func BuildMethodCalls(contract *ContractSchema, methods []*MethodSchema) ([]MethodCall, error) {
+	calls := make([]MethodCall, 0, len(methods))
+	for _, m := range methods {
+		inputs := contract.Abi.Methods[m.Name()].Inputs
+		args := make([]interface{}, 0, len(inputs))
+		for _, in := range inputs {
+			raw, ok := m.Inputs()[in.Name]
+			if !ok {
+				return nil, fmt.Errorf("multicall: missing input %q for method %q", in.Name, m.Name())
+			}
+
+			arg, err := convertInput(in.Type, raw)
+			if err != nil {
+				return nil, fmt.Errorf("multicall: converting input %q for method %q: %w", in.Name, m.Name(), err)
+			}
+			args = append(args, arg)
+		}
+
+		data, err := contract.Abi.Pack(m.Name(), args...)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: packing method %q: %w", m.Name(), err)
+		}
+
+		calls = append(calls, MethodCall{
+			Contract: contract,
+			Method:   m,
+			CallData: data,
+		})
+	}
+
+	return calls, nil
+}
+
+// convertInput converts the raw string a method's `inputs` attribute carries
+// into the Go value abi.Pack expects for argType, mirroring the conversions
+// the eth_call method caller already does for the same MethodSchema.Inputs_
+// map.
+func convertInput(argType abi.Type, raw string) (interface{}, error) {
+	switch argType.T {
+	case abi.AddressTy:
+		return common.HexToAddress(raw), nil
+	case abi.BoolTy:
+		return strconv.ParseBool(raw)
+	case abi.UintTy, abi.IntTy:
+		n, ok := new(big.Int).SetString(raw, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		return n, nil
+	case abi.StringTy:
+		return raw, nil
+	case abi.BytesTy:
+		return common.FromHex(raw), nil
+	case abi.FixedBytesTy:
+		b := common.FromHex(raw)
+		return padFixedBytes(argType, b), nil
+	default:
+		return nil, fmt.Errorf("unsupported input type %s", argType.String())
+	}
+}
+
+// padFixedBytes copies b into a [N]byte array value matching argType's Go
+// type (e.g. [32]byte for bytes32, [4]byte for bytes4), since abi.Pack
+// requires a genuine fixed-size array for FixedBytesTy and rejects a []byte
+// slice for anything but bytes32.
+func padFixedBytes(argType abi.Type, b []byte) interface{} {
+	arr := reflect.New(argType.GetType()).Elem()
+	reflect.Copy(arr, reflect.ValueOf(b))
+	return arr.Interface()
+}
+
+// DecodeMethodResults matches aggregate3 results back to the MethodCalls
+// that produced them and unpacks each successful return blob into an
+// Outputs-keyed map, the same shape GenerateContextVars expects. A call with
+// AllowFailure semantics that reverted yields a nil map and no error, so the
+// caller can decide how to treat a partial batch failure.
+func DecodeMethodResults(calls []MethodCall, results []Result) ([]map[string]interface{}, error) {
+	if len(calls) != len(results) {
+		return nil, fmt.Errorf("multicall: got %d results for %d calls", len(results), len(calls))
+	}
+
+	outputs := make([]map[string]interface{}, len(calls))
+	for i, call := range calls {
+		if !results[i].Success {
+			continue
+		}
+
+		values, err := call.Contract.Abi.Unpack(call.Method.Name(), results[i].ReturnData)
+		if err != nil {
+			return nil, fmt.Errorf("multicall: unpacking method %q: %w", call.Method.Name(), err)
+		}
+
+		out := make(map[string]interface{})
+		for j, name := range call.Method.Outputs {
+			if j < len(values) {
+				out[name] = values[j]
+			}
+		}
+		outputs[i] = out
+	}
+
+	return outputs, nil
+}
+
+// ToCall3 converts a batch of MethodCalls into Multicall3 Call3 structs,
+// targeting each call's own contract address with AllowFailure always set.
+func ToCall3(calls []MethodCall) []Call3 {
+	call3s := make([]Call3, len(calls))
+	for i, c := range calls {
+		call3s[i] = Call3{
+			Target:       c.Contract.Address(),
+			AllowFailure: true,
+			CallData:     c.CallData,
+		}
+	}
+
+	return call3s
+}
+
+// chainsWithMulticall3 lists chains known to have the canonical Multicall3
+// deployment. It's informational only; MulticallAddress always falls back
+// to the canonical address regardless, since the deployer is deterministic.
+var chainsWithMulticall3 = map[types.Chain]bool{
+	types.Ethereum: true,
+}
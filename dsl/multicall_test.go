@@ -0,0 +1,70 @@
+package dsl
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const balanceOfABI = `[{
+	"constant": true,
+	"inputs": [{"name": "owner", "type": "address"}],
+	"name": "balanceOf",
+	"outputs": [{"name": "balance", "type": "uint256"}],
+	"stateMutability": "view",
+	"type": "function"
+}]`
+
+// TestDecodeMethodResultsPartialFailure ensures a single reverting call in
+// an aggregate3 batch (Success = false) doesn't prevent the other calls'
+// results from being decoded: the failing call should yield a nil map and
+// no error, while its neighbours decode normally.
+func TestDecodeMethodResultsPartialFailure(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(balanceOfABI))
+	if err != nil {
+		t.Fatalf("parsing test ABI: %v", err)
+	}
+
+	contract := &ContractSchema{Abi: parsed}
+	method := &MethodSchema{Name_: "balanceOf", Outputs: []string{"balance"}}
+
+	calls := []MethodCall{
+		{Contract: contract, Method: method},
+		{Contract: contract, Method: method},
+		{Contract: contract, Method: method},
+	}
+
+	returnData, err := parsed.Methods["balanceOf"].Outputs.Pack(big.NewInt(42))
+	if err != nil {
+		t.Fatalf("packing success return data: %v", err)
+	}
+
+	results := []Result{
+		{Success: true, ReturnData: returnData},
+		{Success: false, ReturnData: nil}, // reverted call
+		{Success: true, ReturnData: returnData},
+	}
+
+	outputs, err := DecodeMethodResults(calls, results)
+	if err != nil {
+		t.Fatalf("DecodeMethodResults returned error for partial failure: %v", err)
+	}
+
+	if len(outputs) != 3 {
+		t.Fatalf("expected 3 output entries, got %d", len(outputs))
+	}
+
+	if outputs[0]["balance"].(*big.Int).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("call 0: expected balance 42, got %v", outputs[0]["balance"])
+	}
+
+	if outputs[1] != nil {
+		t.Errorf("call 1 (reverted): expected nil outputs, got %v", outputs[1])
+	}
+
+	if outputs[2]["balance"].(*big.Int).Cmp(big.NewInt(42)) != 0 {
+		t.Errorf("call 2: expected balance 42, got %v", outputs[2]["balance"])
+	}
+}
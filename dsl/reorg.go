@@ -0,0 +1,145 @@
+package dsl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/chainbound/apollo/types"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrConfirmationsWithoutRealtime is returned when `confirmations` is set
+// on a query that isn't running in realtime mode, since reorg handling only
+// makes sense against a live chain head.
+var ErrConfirmationsWithoutRealtime = errors.New("confirmations defined for non-realtime query")
+
+// ReorgSink receives a synthetic, reverted CallResult for every row that a
+// detected reorg invalidated, along with the outputs EvalSave recomputed
+// for it, so output drivers can delete or negate what they previously
+// wrote for it.
+type ReorgSink interface {
+	WriteReorg(res types.CallResult, outputs map[string]cty.Value) error
+}
+
+// blockKey identifies a buffered block by both hash and number, since a
+// reorg is exactly a new block arriving at an already-buffered number with
+// a different hash.
+type blockKey struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// ReorgBuffer retains the last Confirmations blocks' worth of emitted
+// CallResults per query, keyed by (BlockHash, BlockNumber), so that when a
+// new head arrives with a canonical hash that doesn't match what's
+// buffered at that number, the runtime can replay EvalSave for every row
+// buffered under the stale hash with Type = types.Reorg and Reverted =
+// true, and then forget it.
+type ReorgBuffer struct {
+	confirmations int64
+	emitReorgs    bool
+
+	rows map[blockKey][]types.CallResult
+	// order preserves insertion order per block number so old entries can
+	// be evicted once they fall outside the confirmation window.
+	order []blockKey
+}
+
+// NewReorgBuffer returns a ReorgBuffer for a query configured with the
+// given confirmations depth. emitReorgs controls whether EvalReorg actually
+// replays rows on a mismatch, or only evicts them silently.
+func NewReorgBuffer(confirmations int64, emitReorgs bool) *ReorgBuffer {
+	return &ReorgBuffer{
+		confirmations: confirmations,
+		emitReorgs:    emitReorgs,
+		rows:          make(map[blockKey][]types.CallResult),
+	}
+}
+
+// Buffer records res as having been emitted for its (BlockHash,
+// BlockNumber), and evicts any block that has fallen outside the
+// confirmation window relative to res.BlockNumber.
+func (b *ReorgBuffer) Buffer(res types.CallResult) {
+	key := blockKey{Number: res.BlockNumber, Hash: res.BlockHash}
+	if _, ok := b.rows[key]; !ok {
+		b.order = append(b.order, key)
+	}
+	b.rows[key] = append(b.rows[key], res)
+
+	b.evict(res.BlockNumber)
+}
+
+func (b *ReorgBuffer) evict(head uint64) {
+	cutoff := int64(head) - b.confirmations
+	if cutoff <= 0 {
+		return
+	}
+
+	remaining := b.order[:0]
+	for _, key := range b.order {
+		if int64(key.Number) < cutoff {
+			delete(b.rows, key)
+			continue
+		}
+		remaining = append(remaining, key)
+	}
+	b.order = remaining
+}
+
+// CheckHead compares canonicalHash (the hash the chain client reports for
+// blockNumber after a new head arrives) against whatever is buffered for
+// that number. If a different hash is buffered, every row buffered under
+// it is canonically gone; CheckHead returns those rows (with Reverted set)
+// for the caller to replay through EvalSave, and drops them from the
+// buffer. Returns nil if nothing was buffered at blockNumber, or if the
+// buffered hash already matches.
+func (b *ReorgBuffer) CheckHead(blockNumber uint64, canonicalHash common.Hash) []types.CallResult {
+	var reorged []types.CallResult
+
+	remaining := b.order[:0]
+	for _, key := range b.order {
+		if key.Number != blockNumber || key.Hash == canonicalHash {
+			remaining = append(remaining, key)
+			continue
+		}
+
+		for _, res := range b.rows[key] {
+			res.Type = types.Reorg
+			res.Reverted = true
+			reorged = append(reorged, res)
+		}
+		delete(b.rows, key)
+	}
+	b.order = remaining
+
+	return reorged
+}
+
+// EvalReorg replays EvalSave for every row invalidated by a detected reorg
+// and hands each result to sink, so it can delete or negate the rows it
+// previously wrote. If the query wasn't configured with emit_reorgs = true,
+// it only drops the invalidated rows from buffer, without replaying them or
+// writing anything to sink.
+func (s *DynamicSchema) EvalReorg(provider ChainFunctionProvider, buffer *ReorgBuffer, blockNumber uint64, canonicalHash common.Hash, sink ReorgSink) error {
+	if !buffer.emitReorgs {
+		buffer.CheckHead(blockNumber, canonicalHash)
+		return nil
+	}
+
+	for _, res := range buffer.CheckHead(blockNumber, canonicalHash) {
+		// Reorg replays recompute outputs to hand to sink, but don't re-index:
+		// the row is being invalidated, not newly written.
+		outputs, err := s.EvalSave(provider, res, nil)
+		if err != nil {
+			return fmt.Errorf("EvalReorg: replaying block %d: %w", res.BlockNumber, err)
+		}
+
+		if err := sink.WriteReorg(res, outputs); err != nil {
+			return fmt.Errorf("EvalReorg: writing reorg for block %d: %w", res.BlockNumber, err)
+		}
+	}
+
+	return nil
+}
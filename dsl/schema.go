@@ -39,6 +39,14 @@ type DynamicSchema struct {
 	BlockInterval int64                `hcl:"block_interval,optional"`
 	Variables     map[string]cty.Value `hcl:"variables,optional"`
 
+	// Multicall, when true, packs every method block within a query (or
+	// within an event's block_offset methods) into a single Multicall3
+	// aggregate3 call per block instead of issuing one eth_call per method.
+	Multicall bool `hcl:"multicall,optional"`
+	// MulticallAddress_ overrides the canonical Multicall3 deployment
+	// address. See MulticallAddress().
+	MulticallAddress_ string `hcl:"multicall_address,optional"`
+
 	// Represents the to-be-decoded queries / loops
 	SchemaConfig hcl.Body `hcl:",remain"`
 
@@ -72,12 +80,35 @@ type QuerySchema struct {
 	Saves   Save     `hcl:"save,block"`
 	Filters hcl.Body `hcl:"filter,remain"`
 
+	// Indexes declares secondary indexes over this query's saved outputs,
+	// for sinks that want fast range-scannable historical queries instead
+	// of a full scan. See EvalIndexes.
+	Indexes []*IndexSchema `hcl:"index,block"`
+
+	// Confirmations, if set, puts this realtime query into reorg-safe mode:
+	// emitted rows are buffered for Confirmations blocks and compared
+	// against canonical head on every new block. See ReorgBuffer.
+	Confirmations int64 `hcl:"confirmations,optional"`
+	// EmitReorgs, when true, replays EvalSave with a synthetic, reverted
+	// result for every row a detected reorg invalidated instead of just
+	// silently dropping it from the buffer.
+	EmitReorgs bool `hcl:"emit_reorgs,optional"`
+
 	// Every query can have its own block intervals,
 	// since it can run on different chains.
 	StartBlock    int64
 	EndBlock      int64
 	BlockInterval int64
 
+	// Source selects which chain data source resolves this query's
+	// contract/event blocks. Defaults to the JSON-RPC eth_getLogs/eth_call
+	// path; set to SourceGraphQL to resolve through a go-ethereum GraphQL
+	// endpoint instead. Can be overridden per contract/event.
+	Source string `hcl:"source,optional"`
+	// GraphQLEndpoint is the go-ethereum GraphQL endpoint to use when
+	// Source (or a contract/event's own Source) is SourceGraphQL.
+	GraphQLEndpoint string `hcl:"graphql_endpoint,optional"`
+
 	EvalContext *hcl.EvalContext
 }
 
@@ -92,14 +123,17 @@ func (q *QuerySchema) EvalTransforms(tp types.ResultType, identifier string) err
 			}
 
 			if event.OutputName() == identifier {
-				mv := make(map[string]cty.Value)
-				diags := gohcl.DecodeBody(event.Transforms.Options, q.EvalContext, &mv)
-				if diags.HasErrors() {
-					return diags.Errs()[0]
+				mv, err := evalAttrsApplyAware(event.Transforms.Options, q.EvalContext)
+				if err != nil {
+					return err
 				}
 
 				for k, v := range mv {
-					q.EvalContext.Variables[k] = v
+					resolved, err := resolveFutures(v)
+					if err != nil {
+						return fmt.Errorf("EvalTransforms: resolving %q: %w", k, err)
+					}
+					q.EvalContext.Variables[k] = resolved
 				}
 			}
 		}
@@ -110,14 +144,17 @@ func (q *QuerySchema) EvalTransforms(tp types.ResultType, identifier string) err
 			}
 
 			if c.Address().String() == identifier {
-				mv := make(map[string]cty.Value)
-				diags := gohcl.DecodeBody(c.Transforms.Options, q.EvalContext, &mv)
-				if diags.HasErrors() {
-					return diags.Errs()[0]
+				mv, err := evalAttrsApplyAware(c.Transforms.Options, q.EvalContext)
+				if err != nil {
+					return err
 				}
 
 				for k, v := range mv {
-					q.EvalContext.Variables[k] = v
+					resolved, err := resolveFutures(v)
+					if err != nil {
+						return fmt.Errorf("EvalTransforms: resolving %q: %w", k, err)
+					}
+					q.EvalContext.Variables[k] = resolved
 				}
 			}
 		}
@@ -169,12 +206,22 @@ type ChainFunctionProvider interface {
 	// Price(types.Chain, common.Address, common.Address, *big.Int) (float64, error)
 }
 
+// rowID derives a stable identifier for a saved row from its CallResult, so
+// an IndexSink can join an index entry back to the row it was derived from.
+func rowID(res types.CallResult) string {
+	return fmt.Sprintf("%s-%d-%s-%d", res.TxHash.Hex(), res.BlockNumber, res.Identifier, res.TxIndex)
+}
+
 // EvalSave updates the evaluation context, evaluates the transform blocks and then
-// evaluates the save block. The results will be returned as a map.
-func (s *DynamicSchema) EvalSave(provider ChainFunctionProvider, res types.CallResult) (map[string]cty.Value, error) {
+// evaluates the save block. The results will be returned as a map. Any index
+// declared on the matching query is evaluated against the saved outputs and
+// written to sink.
+func (s *DynamicSchema) EvalSave(provider ChainFunctionProvider, res types.CallResult, sink IndexSink) (map[string]cty.Value, error) {
 	outputs := make(map[string]cty.Value)
+	var matched *QuerySchema
 	for _, q := range s.QuerySchemas {
 		if q.Name == res.QueryName {
+			matched = q
 			if q.EvalContext.Variables == nil {
 				q.EvalContext.Variables = make(map[string]cty.Value)
 			}
@@ -191,9 +238,20 @@ func (s *DynamicSchema) EvalSave(provider ChainFunctionProvider, res types.CallR
 				return nil, err
 			}
 
-			diags := gohcl.DecodeBody(q.Saves.Options, q.EvalContext, &outputs)
-			if diags.HasErrors() {
-				return nil, diags.Errs()[0]
+			saved, err := evalAttrsApplyAware(q.Saves.Options, q.EvalContext)
+			if err != nil {
+				return nil, err
+			}
+			for k, v := range saved {
+				outputs[k] = v
+			}
+
+			for k, v := range outputs {
+				resolved, err := resolveFutures(v)
+				if err != nil {
+					return nil, fmt.Errorf("EvalSave: resolving %q: %w", k, err)
+				}
+				outputs[k] = resolved
 			}
 		}
 	}
@@ -207,6 +265,12 @@ func (s *DynamicSchema) EvalSave(provider ChainFunctionProvider, res types.CallR
 		return nil, nil
 	}
 
+	if sink != nil && matched != nil && len(matched.Indexes) > 0 {
+		if err := matched.EvalIndexes(outputs, rowID(res), sink); err != nil {
+			return nil, err
+		}
+	}
+
 	return outputs, nil
 }
 
@@ -246,6 +310,12 @@ func (s DynamicSchema) Validate(opts types.ApolloOpts) error {
 		}
 	}
 
+	for _, q := range s.QuerySchemas {
+		if q.Confirmations != 0 && !opts.Realtime {
+			return ErrConfirmationsWithoutRealtime
+		}
+	}
+
 	return nil
 }
 
@@ -273,6 +343,12 @@ func (q QuerySchema) HasContractMethods() (hasContractMethods bool) {
 	return
 }
 
+// ShouldMulticall reports whether c has more than one method block, making
+// it eligible for Multicall3 aggregation instead of one eth_call per method.
+func (c ContractSchema) ShouldMulticall() bool {
+	return len(c.Methods) > 1
+}
+
 type ContractSchema struct {
 	Address_ string `hcl:"address"`
 	AbiPath  string `hcl:"abi"`
@@ -287,14 +363,33 @@ type ContractSchema struct {
 	// data.
 	Transforms *Transform `hcl:"transform,block"`
 
+	// Source overrides the query's source for this contract only.
+	// See QuerySchema.Source.
+	Source string `hcl:"source,optional"`
+
 	// The ABI will get injected when decoding the schema
 	Abi abi.ABI
+
+	// graphQLClient is populated by NewSchema when UsesGraphQL() is true.
+	graphQLClient *GraphQLClient
 }
 
 func (c ContractSchema) Address() common.Address {
 	return common.HexToAddress(c.Address_)
 }
 
+// UsesGraphQL reports whether this contract should be resolved through the
+// GraphQL source instead of JSON-RPC.
+func (c ContractSchema) UsesGraphQL() bool {
+	return c.Source == SourceGraphQL
+}
+
+// GraphQLClient returns the GraphQL client NewSchema built for this
+// contract, or nil if UsesGraphQL() is false.
+func (c ContractSchema) GraphQLClient() *GraphQLClient {
+	return c.graphQLClient
+}
+
 type MethodSchema struct {
 	// BlockOffset is the block offset at which to call the method.
 	// Only used when this method is a method that's supposed to be called
@@ -333,14 +428,40 @@ type EventSchema struct {
 	// data.
 	Transforms *Transform `hcl:"transform,block"`
 
+	// Source overrides the query's source for this event only.
+	// See QuerySchema.Source.
+	Source string `hcl:"source,optional"`
+
 	// The ABI will get injected when decoding the schema
 	Abi abi.ABI
+
+	// graphQLClient is populated by NewSchema when UsesGraphQL() is true.
+	graphQLClient *GraphQLClient
 }
 
 func (e EventSchema) Name() string {
 	return e.Name_
 }
 
+// UsesGraphQL reports whether this event should be resolved through the
+// GraphQL source instead of JSON-RPC.
+func (e EventSchema) UsesGraphQL() bool {
+	return e.Source == SourceGraphQL
+}
+
+// GraphQLClient returns the GraphQL client NewSchema built for this event,
+// or nil if UsesGraphQL() is false.
+func (e EventSchema) GraphQLClient() *GraphQLClient {
+	return e.graphQLClient
+}
+
+// ShouldMulticall reports whether e has more than one method block attached
+// (methods called at block_offset from the event), making it eligible for
+// Multicall3 aggregation instead of one eth_call per method.
+func (e EventSchema) ShouldMulticall() bool {
+	return len(e.Methods) > 1
+}
+
 func (e EventSchema) Outputs() []string {
 	return e.Outputs_
 }
@@ -458,6 +579,16 @@ func NewSchema(confDir string) (*DynamicSchema, error) {
 			}
 
 			event.Abi = abi
+
+			// A top-level event inherits the query's source unless it sets
+			// its own, and gets a GraphQL client wired up when that source
+			// is "graphql" instead of the default eth_getLogs path.
+			if event.Source == "" {
+				event.Source = query.Source
+			}
+			if event.UsesGraphQL() {
+				event.graphQLClient = NewGraphQLClient(query.GraphQLEndpoint)
+			}
 		}
 
 		for _, contract := range query.ContractSchemas {
@@ -472,6 +603,25 @@ func NewSchema(confDir string) (*DynamicSchema, error) {
 			}
 
 			contract.Abi = abi
+
+			// Likewise for per-contract events/methods: inherit the query's
+			// source unless overridden, and wire up a GraphQL client.
+			if contract.Source == "" {
+				contract.Source = query.Source
+			}
+			if contract.UsesGraphQL() {
+				contract.graphQLClient = NewGraphQLClient(query.GraphQLEndpoint)
+			}
+
+			for _, event := range contract.Events {
+				event.Abi = contract.Abi
+				if event.Source == "" {
+					event.Source = contract.Source
+				}
+				if event.UsesGraphQL() {
+					event.graphQLClient = NewGraphQLClient(query.GraphQLEndpoint)
+				}
+			}
 		}
 	}
 
@@ -497,6 +647,8 @@ func GenerateContextVars(cr types.CallResult) map[string]cty.Value {
 		m["tx_index"], _ = gocty.ToCtyValue(cr.TxIndex, cty.Number)
 	}
 
+	m["reverted"], _ = gocty.ToCtyValue(cr.Reverted, cty.Bool)
+
 	for k, v := range cr.Inputs {
 		switch v.(type) {
 		case string:
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Chain identifies the chain a query or call result belongs to.
+type Chain string
+
+const (
+	Ethereum Chain = "ethereum"
+	Arbitrum Chain = "arbitrum"
+	Optimism Chain = "optimism"
+	Polygon  Chain = "polygon"
+)
+
+// ResultType distinguishes what kind of chain activity a CallResult
+// represents.
+type ResultType int
+
+const (
+	// Method is the result of a single eth_call against a contract.
+	Method ResultType = iota
+	// Event is a log emitted by a per-contract `event` block.
+	Event
+	// GlobalEvent is a log emitted by a top-level `event` block.
+	GlobalEvent
+	// Reorg is a synthetic result replayed for a row whose block was
+	// reorged out, so sinks can delete or negate what they previously
+	// wrote for it. See dsl.ReorgBuffer.
+	Reorg
+)
+
+// ApolloOpts carries the run-wide options that affect schema validation and
+// evaluation, such as whether queries are running against a live chain head.
+type ApolloOpts struct {
+	Realtime bool
+}
+
+// CallResult is the result of resolving a single method call or event log,
+// and is the unit of work EvalSave/GenerateContextVars operate on.
+type CallResult struct {
+	Chain Chain
+	Type  ResultType
+
+	QueryName  string
+	Identifier string
+
+	ContractAddress common.Address
+	BlockNumber     uint64
+	BlockHash       common.Hash
+	Timestamp       int64
+
+	TxHash    common.Hash
+	TxIndex   uint
+	EventName string
+
+	Inputs  map[string]interface{}
+	Outputs map[string]interface{}
+
+	// Reverted is set on a CallResult replayed with Type = Reorg, marking
+	// the row it was originally saved under as invalidated by a reorg.
+	Reverted bool
+}